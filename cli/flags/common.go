@@ -23,6 +23,8 @@ const (
 	DefaultCertFile = "cert.pem"
 	// TLSVerifyKey is the default flag name for the tls verification option
 	TLSVerifyKey = "tlsverify"
+	// DefaultConfigFile is the default filename for the daemon configuration file
+	DefaultConfigFile = "/etc/docker/daemon.json"
 )
 
 var (
@@ -30,11 +32,8 @@ var (
 	dockerTLSVerify = os.Getenv("DOCKER_TLS_VERIFY") != ""
 )
 
-// CommonFlags are flags common to both the client and the daemon.
-type CommonFlags struct {
-	FlagSet   *flag.FlagSet
-	PostParse func()
-
+// CommonOptions are the options common to both the client and the daemon.
+type CommonOptions struct {
 	Debug      bool
 	Hosts      []string
 	LogLevel   string
@@ -42,55 +41,82 @@ type CommonFlags struct {
 	TLSVerify  bool
 	TLSOptions *tlsconfig.Options
 	TrustKey   string
+
+	TLSMinVersion       string
+	TLSCipherSuites     []string
+	TLSCRLFile          string
+	TLSPinnedCertSHA256 []string
+
+	LogFormat string
 }
 
-// InitCommonFlags initializes flags common to both client and daemon
-func InitCommonFlags() *CommonFlags {
-	var commonFlags = &CommonFlags{FlagSet: new(flag.FlagSet)}
+// NewCommonOptions returns a new CommonOptions struct with its values
+// zeroed out, ready to be bound to a flag set with Install.
+func NewCommonOptions() *CommonOptions {
+	return &CommonOptions{}
+}
 
+// Install binds the common flags (those shared by the client and the
+// daemon) to the given flag set.
+func (commonOpts *CommonOptions) Install(cmd *flag.FlagSet) {
 	if dockerCertPath == "" {
 		dockerCertPath = cliconfig.ConfigDir()
 	}
 
-	commonFlags.PostParse = func() { postParseCommon(commonFlags) }
-
-	cmd := commonFlags.FlagSet
-
-	cmd.BoolVar(&commonFlags.Debug, []string{"D", "-debug"}, false, "开启调试模式")
-	cmd.StringVar(&commonFlags.LogLevel, []string{"l", "-log-level"}, "info", "设置日志级别")
-	cmd.BoolVar(&commonFlags.TLS, []string{"-tls"}, false, "使用TLS通过参数--tlsverify")
-	cmd.BoolVar(&commonFlags.TLSVerify, []string{"-tlsverify"}, dockerTLSVerify, "使用TLS来验证远程连接")
+	cmd.BoolVar(&commonOpts.Debug, []string{"D", "-debug"}, false, "开启调试模式")
+	cmd.StringVar(&commonOpts.LogLevel, []string{"l", "-log-level"}, "info", "设置日志级别")
+	cmd.StringVar(&commonOpts.LogFormat, []string{"-log-format"}, "text", "设置日志输出格式(text 或 json)")
+	cmd.BoolVar(&commonOpts.TLS, []string{"-tls"}, false, "使用TLS通过参数--tlsverify")
+	cmd.BoolVar(&commonOpts.TLSVerify, []string{"-tlsverify"}, dockerTLSVerify, "使用TLS来验证远程连接")
 
 	// TODO use flag flag.String([]string{"i", "-identity"}, "", "Path to libtrust key file")
 
 	var tlsOptions tlsconfig.Options
-	commonFlags.TLSOptions = &tlsOptions
+	commonOpts.TLSOptions = &tlsOptions
 	cmd.StringVar(&tlsOptions.CAFile, []string{"-tlscacert"}, filepath.Join(dockerCertPath, DefaultCaFile), "仅被CA签名的受信certs路径信息")
 	cmd.StringVar(&tlsOptions.CertFile, []string{"-tlscert"}, filepath.Join(dockerCertPath, DefaultCertFile), "TLS 证书文件的路径信息")
 	cmd.StringVar(&tlsOptions.KeyFile, []string{"-tlskey"}, filepath.Join(dockerCertPath, DefaultKeyFile), "TLS 密钥文件路径信息")
 
-	cmd.Var(opts.NewNamedListOptsRef("hosts", &commonFlags.Hosts, opts.ValidateHost), []string{"H", "-host"}, "Docker引擎监听的套接字")
-	return commonFlags
-}
+	cmd.StringVar(&commonOpts.TLSMinVersion, []string{"-tls-min-version"}, "", "允许的最低TLS协议版本(如 tls1.0、tls1.1、tls1.2)")
+	cmd.Var(opts.NewListOptsRef(&commonOpts.TLSCipherSuites, nil), []string{"-tls-cipher-suites"}, "允许使用的TLS加密套件,可重复指定")
+	cmd.StringVar(&commonOpts.TLSCRLFile, []string{"-tlscrlfile"}, "", "包含已吊销证书列表(CRL)的文件路径")
+	cmd.Var(opts.NewListOptsRef(&commonOpts.TLSPinnedCertSHA256, nil), []string{"-tls-pinned-cert-sha256"}, "仅信任指定SHA256指纹(base64编码的SPKI)的对端证书,可重复指定")
 
-func postParseCommon(commonFlags *CommonFlags) {
-	cmd := commonFlags.FlagSet
+	cmd.Var(opts.NewNamedListOptsRef("hosts", &commonOpts.Hosts, opts.ValidateHost), []string{"H", "-host"}, "Docker引擎监听的套接字")
 
-	SetDaemonLogLevel(commonFlags.LogLevel)
+	// Let DOCKER_* environment variables and ~/.docker/config.json fill
+	// in defaults for the flags above before the caller parses cmd, so
+	// CLI arguments still take precedence but CI environments don't
+	// have to repeat themselves on every invocation.
+	configPath := filepath.Join(cliconfig.ConfigDir(), "config.json")
+	Resolve(cmd, "DOCKER", LoadConfigFallback(configPath))
+}
+
+// SetDefaultOptions fills in the implied and derived defaults for the
+// common options once the flag set has been parsed by the caller. It
+// must be called after the owning flag set's Parse, and its error must
+// not be ignored: a non-nil error means the combination of flags the
+// user passed is unsafe to run with.
+func (commonOpts *CommonOptions) SetDefaultOptions(cmd *flag.FlagSet) error {
+	ConfigureLogging(commonOpts.LogLevel, commonOpts.LogFormat, nil)
 
 	// Regardless of whether the user sets it to true or false, if they
 	// specify --tlsverify at all then we need to turn on tls
 	// TLSVerify can be true even if not set due to DOCKER_TLS_VERIFY env var, so we need
 	// to check that here as well
-	if cmd.IsSet("-"+TLSVerifyKey) || commonFlags.TLSVerify {
-		commonFlags.TLS = true
+	if cmd.IsSet("-"+TLSVerifyKey) || commonOpts.TLSVerify {
+		commonOpts.TLS = true
+	}
+
+	if !commonOpts.TLSVerify && (commonOpts.TLSCRLFile != "" || len(commonOpts.TLSPinnedCertSHA256) > 0) {
+		return fmt.Errorf("--tlscrlfile 和 --tls-pinned-cert-sha256 要求同时开启 --tlsverify,否则配置将被静默忽略")
 	}
 
-	if !commonFlags.TLS {
-		commonFlags.TLSOptions = nil
+	if !commonOpts.TLS {
+		commonOpts.TLSOptions = nil
 	} else {
-		tlsOptions := commonFlags.TLSOptions
-		tlsOptions.InsecureSkipVerify = !commonFlags.TLSVerify
+		tlsOptions := commonOpts.TLSOptions
+		tlsOptions.InsecureSkipVerify = !commonOpts.TLSVerify
 
 		// Reset CertFile and KeyFile to empty string if the user did not specify
 		// the respective flags and the respective default files were not found.
@@ -105,6 +131,57 @@ func postParseCommon(commonFlags *CommonFlags) {
 			}
 		}
 	}
+
+	return nil
+}
+
+// ClientOptions are the options for the docker client, layering its own
+// flags (ConfigDir, Version) on top of the options it shares with the
+// daemon through the embedded *CommonOptions.
+type ClientOptions struct {
+	*CommonOptions
+	ConfigDir string
+	Version   bool
+}
+
+// NewClientOptions returns a new ClientOptions struct with a fresh
+// CommonOptions embedded, ready to be bound to a flag set with Install.
+func NewClientOptions() *ClientOptions {
+	return &ClientOptions{CommonOptions: NewCommonOptions()}
+}
+
+// Install binds the client-only flags, as well as the common flags
+// shared with the daemon, to the given flag set.
+func (clientOpts *ClientOptions) Install(cmd *flag.FlagSet) {
+	clientOpts.CommonOptions.Install(cmd)
+
+	cmd.StringVar(&clientOpts.ConfigDir, []string{"-config"}, cliconfig.ConfigDir(), "客户端配置文件所在路径")
+	cmd.BoolVar(&clientOpts.Version, []string{"v", "-version"}, false, "显示版本信息并退出")
+}
+
+// DaemonOptions are the options for the dockerd entry point, layering
+// its own flags (ConfigFile) on top of the options it shares with the
+// client through the embedded *CommonOptions. --config-file lives here,
+// not on CommonOptions, because the client never reads it: surfacing it
+// on `docker --help` would be a dead flag, contradicting the whole
+// point of splitting daemon-only flags out from the shared ones.
+type DaemonOptions struct {
+	*CommonOptions
+	ConfigFile string
+}
+
+// NewDaemonOptions returns a new DaemonOptions struct with a fresh
+// CommonOptions embedded, ready to be bound to a flag set with Install.
+func NewDaemonOptions() *DaemonOptions {
+	return &DaemonOptions{CommonOptions: NewCommonOptions()}
+}
+
+// Install binds the daemon-only flags, as well as the common flags
+// shared with the client, to the given flag set.
+func (daemonOpts *DaemonOptions) Install(cmd *flag.FlagSet) {
+	daemonOpts.CommonOptions.Install(cmd)
+
+	cmd.StringVar(&daemonOpts.ConfigFile, []string{"-config-file"}, DefaultConfigFile, "守护进程JSON配置文件的路径")
 }
 
 // SetDaemonLogLevel sets the logrus logging level
@@ -0,0 +1,106 @@
+package flags
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// installCallerHookOnce guards against ConfigureLogging being called
+// more than once with --debug on (the daemon's SIGHUP reload does
+// exactly that): without it every call would append another caller
+// hook to logrus' global hook list.
+var installCallerHookOnce sync.Once
+
+// ConfigureLogging sets logrus' level, output formatter and writer. It
+// supersedes SetDaemonLogLevel, which only ever touched the level.
+// format selects between "text" (the default, kept for backward
+// compatibility) and "json"; a nil output leaves logrus writing to its
+// current destination (os.Stderr unless something else already
+// redirected it).
+func ConfigureLogging(level, format string, output io.Writer) {
+	if output != nil {
+		logrus.SetOutput(output)
+	}
+
+	SetDaemonLogLevel(level)
+
+	switch format {
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyMsg:   "msg",
+				logrus.FieldKeyLevel: "level",
+				logrus.FieldKeyTime:  "time",
+			},
+		})
+	case "", "text":
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	default:
+		fmt.Fprintf(os.Stderr, "未知的日志格式: %s, 回退到text\n", format)
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	}
+
+	// The vendored logrus predates logrus.SetReportCaller, so when
+	// --debug is on we get the same "caller" field via a hook instead.
+	// The hook only needs to be installed once; it checks the current
+	// level on every Fire so it stays silent again if --debug is later
+	// turned off (e.g. via a SIGHUP config reload).
+	if logrus.GetLevel() == logrus.DebugLevel {
+		installCallerHookOnce.Do(func() {
+			AddLoggingHook(newCallerHook())
+		})
+	}
+}
+
+// AddLoggingHook registers an additional logrus hook, for example a
+// syslog or journald forwarder, without requiring the caller to import
+// logrus directly or patch this package.
+func AddLoggingHook(hook logrus.Hook) {
+	logrus.AddHook(hook)
+}
+
+// callerHook stamps each log entry with the file:line of its call site,
+// standing in for logrus.SetReportCaller on the vendored logrus version
+// this package builds against.
+type callerHook struct{}
+
+func newCallerHook() *callerHook {
+	return &callerHook{}
+}
+
+func (h *callerHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *callerHook) Fire(entry *logrus.Entry) error {
+	// Levels() has to return logrus.AllLevels so the hook keeps firing
+	// across a debug->non-debug reload (SIGHUP can flip --debug off per
+	// reload.go's reloadableFields); it tracks the *current* level itself
+	// instead so "--debug off" actually stops the annotation.
+	if logrus.GetLevel() != logrus.DebugLevel {
+		return nil
+	}
+	if _, ok := entry.Data["caller"]; ok {
+		return nil
+	}
+	for skip := 2; skip < 12; skip++ {
+		_, file, line, ok := runtime.Caller(skip)
+		if !ok {
+			break
+		}
+		if strings.Contains(file, "Sirupsen/logrus") {
+			continue
+		}
+		entry.Data["caller"] = fmt.Sprintf("%s:%d", file, line)
+		break
+	}
+	return nil
+}
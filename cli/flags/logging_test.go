@@ -0,0 +1,51 @@
+package flags
+
+import (
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// TestCallerHookTracksCurrentLevel covers the exact gap the caller hook
+// had: once installed it must stop stamping "caller" the moment the
+// level drops back below debug, rather than remembering that debug was
+// on at some point in the past.
+func TestCallerHookTracksCurrentLevel(t *testing.T) {
+	defer logrus.SetLevel(logrus.InfoLevel)
+
+	hook := newCallerHook()
+
+	logrus.SetLevel(logrus.DebugLevel)
+	debugEntry := &logrus.Entry{Data: logrus.Fields{}}
+	if err := hook.Fire(debugEntry); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := debugEntry.Data["caller"]; !ok {
+		t.Fatal("期望debug级别下caller字段被打上标记")
+	}
+
+	logrus.SetLevel(logrus.InfoLevel)
+	infoEntry := &logrus.Entry{Data: logrus.Fields{}}
+	if err := hook.Fire(infoEntry); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := infoEntry.Data["caller"]; ok {
+		t.Fatal("关闭--debug后不应再打上caller字段")
+	}
+}
+
+// TestConfigureLoggingInstallsHookOnce guards against the hook being
+// re-appended to logrus' global hook list on every ConfigureLogging
+// call, which is exactly what a repeated SIGHUP reload does.
+func TestConfigureLoggingInstallsHookOnce(t *testing.T) {
+	defer logrus.SetLevel(logrus.InfoLevel)
+
+	before := len(logrus.StandardLogger().Hooks[logrus.DebugLevel])
+	ConfigureLogging("debug", "text", nil)
+	ConfigureLogging("debug", "text", nil)
+	after := len(logrus.StandardLogger().Hooks[logrus.DebugLevel])
+
+	if after-before != 1 {
+		t.Fatalf("期望caller hook只安装一次, 实际新增了%d个", after-before)
+	}
+}
@@ -0,0 +1,138 @@
+package flags
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+// resolvable describes one flag that Resolve knows how to fall back for:
+// its registered long flag name, the DOCKER_* environment variable
+// suffix (envPrefix + "_" + env), and the key it is stored under in the
+// ~/.docker/config.json "common" section.
+type resolvable struct {
+	flagName  string
+	env       string
+	configKey string
+	multi     bool
+}
+
+// resolvableFlags is deliberately a short, explicit list rather than
+// every registered flag: these are the ones users actually want to set
+// once in CI environments instead of repeating on every invocation.
+var resolvableFlags = []resolvable{
+	{"-host", "HOST", "host", true},
+	{"-log-level", "LOG_LEVEL", "log-level", false},
+	{"-tls", "TLS", "tls", false},
+	{"-tlscacert", "TLSCACERT", "tlscacert", false},
+	{"-tlscert", "TLSCERT", "tlscert", false},
+	{"-tlskey", "TLSKEY", "tlskey", false},
+}
+
+// Resolve fills in the flags registered on cmd from, in order, the
+// matching envPrefix+"_"+env environment variable and then cfg
+// (typically the "common" section of ~/.docker/config.json). It must
+// run after the flags are registered but before cmd.Parse, so that a
+// value the user passes on the command line still wins: cmd.Parse runs
+// afterwards and simply sets the flag again.
+//
+// Resolve applies values through cmd.Set rather than writing the
+// flag's Value directly, so a flag Resolve fills in from DOCKER_HOST or
+// config.json is marked IsSet exactly like one passed on the command
+// line. This matters beyond this package: daemon/config's
+// MergeDaemonConfigurations uses FlagSet.IsSet to decide whether the
+// user "explicitly set" a value, and a value an admin deliberately
+// exported as DOCKER_HOST deserves the same conflict protection as the
+// same value passed as --host.
+func Resolve(cmd *flag.FlagSet, envPrefix string, cfg map[string]interface{}) {
+	for _, r := range resolvableFlags {
+		if cmd.Lookup(r.flagName) == nil {
+			continue
+		}
+
+		value, ok := lookupEnv(envPrefix, r.env)
+		if !ok {
+			value, ok = lookupConfig(cfg, r.configKey)
+		}
+		if !ok {
+			continue
+		}
+
+		if !r.multi {
+			if err := cmd.Set(r.flagName, value); err != nil {
+				fmt.Fprintf(os.Stderr, "忽略无效的 %s_%s: %v\n", envPrefix, r.env, err)
+			}
+			continue
+		}
+
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			if err := cmd.Set(r.flagName, part); err != nil {
+				fmt.Fprintf(os.Stderr, "忽略无效的 %s_%s: %v\n", envPrefix, r.env, err)
+			}
+		}
+	}
+}
+
+func lookupEnv(envPrefix, name string) (string, bool) {
+	value := os.Getenv(envPrefix + "_" + name)
+	return value, value != ""
+}
+
+func lookupConfig(cfg map[string]interface{}, key string) (string, bool) {
+	raw, ok := cfg[key]
+	if !ok {
+		return "", false
+	}
+
+	switch v := raw.(type) {
+	case string:
+		return v, v != ""
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ","), len(parts) > 0
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "", false
+	default:
+		return "", false
+	}
+}
+
+// LoadConfigFallback reads the "common" section of the docker client
+// config file at path for use as the config-file fallback tier of
+// Resolve. A missing file, a malformed file, or a file with no
+// "common" section is not an error: it simply yields an empty map, so
+// Resolve falls through to compiled-in defaults.
+func LoadConfigFallback(path string) map[string]interface{} {
+	cfg := map[string]interface{}{}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg
+	}
+
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return cfg
+	}
+
+	if raw, ok := root["common"]; ok {
+		json.Unmarshal(raw, &cfg)
+	}
+	return cfg
+}
@@ -0,0 +1,105 @@
+package flags
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+func newResolvableFlagSet() (*flag.FlagSet, *CommonOptions) {
+	cmd := flag.NewFlagSet("test", flag.ContinueOnError)
+	commonOpts := NewCommonOptions()
+	cmd.Var(opts.NewListOptsRef(&commonOpts.Hosts, nil), []string{"H", "-host"}, "")
+	cmd.StringVar(&commonOpts.LogLevel, []string{"l", "-log-level"}, "info", "")
+	cmd.BoolVar(&commonOpts.TLS, []string{"-tls"}, false, "")
+	var caFile string
+	cmd.StringVar(&caFile, []string{"-tlscacert"}, "", "")
+	return cmd, commonOpts
+}
+
+func TestResolveEnvWinsOverConfig(t *testing.T) {
+	cmd, commonOpts := newResolvableFlagSet()
+
+	os.Setenv("DOCKER_LOG_LEVEL", "debug")
+	defer os.Unsetenv("DOCKER_LOG_LEVEL")
+
+	Resolve(cmd, "DOCKER", map[string]interface{}{"log-level": "warn"})
+	if err := cmd.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if commonOpts.LogLevel != "debug" {
+		t.Fatalf("期望环境变量优先于配置文件, 实际日志级别为 %s", commonOpts.LogLevel)
+	}
+}
+
+func TestResolveConfigFallsBackWhenNoEnv(t *testing.T) {
+	cmd, commonOpts := newResolvableFlagSet()
+
+	Resolve(cmd, "DOCKER", map[string]interface{}{"log-level": "warn"})
+	if err := cmd.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if commonOpts.LogLevel != "warn" {
+		t.Fatalf("期望在没有环境变量时回退到配置文件, 实际日志级别为 %s", commonOpts.LogLevel)
+	}
+}
+
+func TestResolveHostsSplitsOnComma(t *testing.T) {
+	cmd, commonOpts := newResolvableFlagSet()
+
+	os.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375,unix:///var/run/docker.sock")
+	defer os.Unsetenv("DOCKER_HOST")
+
+	Resolve(cmd, "DOCKER", nil)
+	if err := cmd.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"tcp://127.0.0.1:2375", "unix:///var/run/docker.sock"}
+	if len(commonOpts.Hosts) != len(want) {
+		t.Fatalf("期望解析出%d个host, 实际为%v", len(want), commonOpts.Hosts)
+	}
+	for i, h := range want {
+		if commonOpts.Hosts[i] != h {
+			t.Fatalf("期望第%d个host为%s, 实际为%s", i, h, commonOpts.Hosts[i])
+		}
+	}
+}
+
+func TestResolveBoolConfigValue(t *testing.T) {
+	cmd, commonOpts := newResolvableFlagSet()
+
+	Resolve(cmd, "DOCKER", map[string]interface{}{"tls": true})
+	if err := cmd.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !commonOpts.TLS {
+		t.Fatal("期望配置文件中的布尔值tls=true生效")
+	}
+}
+
+// TestResolveMarksFlagAsSetForDaemonMerge is the regression test for the
+// gap a review caught: a value Resolve fills in from DOCKER_HOST must
+// be just as "explicitly set" as one passed on the command line, so
+// daemon/config.MergeDaemonConfigurations raises the same conflict
+// error it would for --host.
+func TestResolveMarksFlagAsSetForDaemonMerge(t *testing.T) {
+	cmd, _ := newResolvableFlagSet()
+
+	os.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	defer os.Unsetenv("DOCKER_HOST")
+
+	Resolve(cmd, "DOCKER", nil)
+	if err := cmd.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cmd.IsSet("-host") {
+		t.Fatal("期望通过DOCKER_HOST解析出的值在FlagSet中被标记为已设置")
+	}
+}
@@ -0,0 +1,137 @@
+package flags
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+var tlsVersions = map[string]uint16{
+	"tls1.0": tls.VersionTLS10,
+	"tls1.1": tls.VersionTLS11,
+	"tls1.2": tls.VersionTLS12,
+}
+
+var tlsCipherSuites = map[string]uint16{
+	"TLS_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+}
+
+// TLSConfig builds the *tls.Config described by commonOpts: the base
+// client certificate configuration that tlsconfig.Client derives from
+// commonOpts.TLSOptions (no server-side configuration is built here),
+// narrowed by TLSMinVersion/TLSCipherSuites, and hardened with a
+// VerifyPeerCertificate hook that enforces certificate pinning
+// (TLSPinnedCertSHA256) and CRL revocation (TLSCRLFile) once standard
+// chain verification has already succeeded.
+func (commonOpts *CommonOptions) TLSConfig() (*tls.Config, error) {
+	if commonOpts.TLSOptions == nil {
+		return nil, nil
+	}
+
+	tlsConfig, err := tlsconfig.Client(*commonOpts.TLSOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if commonOpts.TLSMinVersion != "" {
+		version, ok := tlsVersions[commonOpts.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("未知的 --tls-min-version: %s", commonOpts.TLSMinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if len(commonOpts.TLSCipherSuites) > 0 {
+		var suites []uint16
+		for _, name := range commonOpts.TLSCipherSuites {
+			suite, ok := tlsCipherSuites[name]
+			if !ok {
+				return nil, fmt.Errorf("未知的 --tls-cipher-suites: %s", name)
+			}
+			suites = append(suites, suite)
+		}
+		tlsConfig.CipherSuites = suites
+	}
+
+	var crl *pkix.CertificateList
+	if commonOpts.TLSCRLFile != "" {
+		crl, err = loadCRLFile(commonOpts.TLSCRLFile)
+		if err != nil {
+			return nil, fmt.Errorf("无法加载 --tlscrlfile %s: %v", commonOpts.TLSCRLFile, err)
+		}
+	}
+
+	if crl != nil || len(commonOpts.TLSPinnedCertSHA256) > 0 {
+		tlsConfig.InsecureSkipVerify = false
+		tlsConfig.VerifyPeerCertificate = verifyPinsAndCRL(commonOpts.TLSPinnedCertSHA256, crl)
+	}
+
+	return tlsConfig, nil
+}
+
+func loadCRLFile(path string) (*pkix.CertificateList, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return x509.ParseCRL(data)
+}
+
+// verifyPinsAndCRL returns a tls.Config.VerifyPeerCertificate hook. Go's
+// TLS stack only calls it after the standard chain verification has
+// already succeeded, so the pin and CRL checks below only ever see
+// certificates that chain to a trusted CA.
+func verifyPinsAndCRL(pins []string, crl *pkix.CertificateList) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+			return fmt.Errorf("没有可用于pin/CRL校验的已验证证书链")
+		}
+
+		if len(pins) > 0 {
+			leaf := verifiedChains[0][0]
+			sum := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+			digest := base64.StdEncoding.EncodeToString(sum[:])
+
+			matched := false
+			for _, pin := range pins {
+				if pin == digest {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf("对端证书的公钥指纹 %s 不在 --tls-pinned-cert-sha256 列表中", digest)
+			}
+		}
+
+		if crl != nil {
+			for _, chain := range verifiedChains {
+				for _, cert := range chain {
+					for _, revoked := range crl.TBSCertList.RevokedCertificates {
+						if cert.SerialNumber.Cmp(revoked.SerialNumber) == 0 {
+							return fmt.Errorf("证书 %s (序列号 %s) 已被CRL吊销", cert.Subject, cert.SerialNumber)
+						}
+					}
+				}
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,103 @@
+package flags
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func generateSelfSignedCert(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "flags-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestVerifyPinsAndCRLPinMatches(t *testing.T) {
+	cert, _ := generateSelfSignedCert(t)
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(sum[:])
+
+	verify := verifyPinsAndCRL([]string{pin}, nil)
+	if err := verify(nil, [][]*x509.Certificate{{cert}}); err != nil {
+		t.Fatalf("期望匹配的pin可以通过校验, 实际返回错误: %v", err)
+	}
+}
+
+func TestVerifyPinsAndCRLPinMismatch(t *testing.T) {
+	cert, _ := generateSelfSignedCert(t)
+
+	verify := verifyPinsAndCRL([]string{"bm90LWEtcmVhbC1waW4="}, nil)
+	if err := verify(nil, [][]*x509.Certificate{{cert}}); err == nil {
+		t.Fatal("期望不匹配的pin被拒绝, 实际没有出错")
+	}
+}
+
+func TestVerifyPinsAndCRLRevoked(t *testing.T) {
+	cert, key := generateSelfSignedCert(t)
+
+	revoked := []pkix.RevokedCertificate{
+		{SerialNumber: cert.SerialNumber, RevocationTime: time.Now()},
+	}
+	crlDER, err := cert.CreateCRL(rand.Reader, key, revoked, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := x509.ParseCRL(crlDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verify := verifyPinsAndCRL(nil, crl)
+	if err := verify(nil, [][]*x509.Certificate{{cert}}); err == nil {
+		t.Fatal("期望已吊销的证书被拒绝, 实际没有出错")
+	}
+}
+
+func TestVerifyPinsAndCRLNotRevoked(t *testing.T) {
+	cert, key := generateSelfSignedCert(t)
+	other := big.NewInt(999)
+
+	revoked := []pkix.RevokedCertificate{
+		{SerialNumber: other, RevocationTime: time.Now()},
+	}
+	crlDER, err := cert.CreateCRL(rand.Reader, key, revoked, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := x509.ParseCRL(crlDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verify := verifyPinsAndCRL(nil, crl)
+	if err := verify(nil, [][]*x509.Certificate{{cert}}); err != nil {
+		t.Fatalf("证书序列号不在CRL中时不应被拒绝, 实际返回: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+// Package config defines the on-disk daemon configuration file and the
+// rules for merging it with the flags parsed on the command line.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+// Config mirrors the fields exposed on the command line by
+// flags.CommonOptions, plus the handful of options that only make sense
+// for the daemon itself.
+type Config struct {
+	Debug     bool     `json:"debug,omitempty"`
+	Hosts     []string `json:"hosts,omitempty"`
+	LogLevel  string   `json:"log-level,omitempty"`
+	TLS       bool     `json:"tls,omitempty"`
+	TLSVerify bool     `json:"tlsverify,omitempty"`
+	TLSCACert string   `json:"tlscacert,omitempty"`
+	TLSCert   string   `json:"tlscert,omitempty"`
+	TLSKey    string   `json:"tlskey,omitempty"`
+
+	TLSOptions *tlsconfig.Options `json:"-"`
+
+	// GraphDriver and GroupName are daemon-only; the client never sets
+	// them so they have no CommonOptions equivalent.
+	GraphDriver string `json:"storage-driver,omitempty"`
+	GroupName   string `json:"group,omitempty"`
+}
+
+// field describes one merge-able configuration key: the name it is
+// addressed by in the JSON file (and in presentKeys) and the flag name
+// it is bound to on the daemon's flag set. file reads the key's value
+// out of a Config; it is used both for the file's value and, by
+// passing the flag-derived Config, for the flag's value.
+type field struct {
+	key      string
+	flagName string
+	file     func(*Config) interface{}
+}
+
+func fields(cfg *Config) []field {
+	return []field{
+		{"debug", "-debug", func(c *Config) interface{} { return c.Debug }},
+		{"hosts", "-host", func(c *Config) interface{} { return c.Hosts }},
+		{"log-level", "-log-level", func(c *Config) interface{} { return c.LogLevel }},
+		{"tls", "-tls", func(c *Config) interface{} { return c.TLS }},
+		{"tlsverify", "-tlsverify", func(c *Config) interface{} { return c.TLSVerify }},
+		{"tlscacert", "-tlscacert", func(c *Config) interface{} { return c.TLSCACert }},
+		{"tlscert", "-tlscert", func(c *Config) interface{} { return c.TLSCert }},
+		{"tlskey", "-tlskey", func(c *Config) interface{} { return c.TLSKey }},
+		{"storage-driver", "-storage-driver", func(c *Config) interface{} { return c.GraphDriver }},
+		{"group", "-group", func(c *Config) interface{} { return c.GroupName }},
+	}
+}
+
+// New returns a Config populated with the daemon's compiled-in defaults.
+func New() *Config {
+	return &Config{
+		LogLevel: "info",
+	}
+}
+
+// LoadFile reads and parses the daemon configuration file at path,
+// overlaying it onto the compiled-in defaults. A missing file is not an
+// error: LoadFile returns the defaults unchanged so callers can merge
+// unconditionally.
+func LoadFile(path string) (*Config, error) {
+	config := New()
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(config); err != nil {
+		return nil, fmt.Errorf("无法解析配置文件 %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// presentKeys returns the set of top-level JSON keys actually written
+// in the configuration file at path. This is distinct from asking
+// whether a decoded Config field is non-zero: New()'s compiled-in
+// defaults (e.g. LogLevel "info") would otherwise look identical to a
+// value the file genuinely set, and MergeDaemonConfigurations needs to
+// tell those apart to decide whether the file "explicitly set" a key.
+// A missing file yields an empty, non-error set.
+func presentKeys(path string) (map[string]bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]bool{}, nil
+		}
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("无法解析配置文件 %s: %v", path, err)
+	}
+
+	keys := make(map[string]bool, len(raw))
+	for k := range raw {
+		keys[k] = true
+	}
+	return keys, nil
+}
+
+// MergeDaemonConfigurations merges the daemon configuration file at
+// configFile into existing, which holds the values already parsed from
+// the command line. For every overlapping key, a value the user
+// explicitly set on the command line (per flags.IsSet) wins over a
+// value the file explicitly set (per presentKeys), and the file wins
+// over the compiled-in default otherwise. A key that was explicitly set
+// both on the command line and in the file, with different values, is
+// reported as a single descriptive error listing every offending key.
+func MergeDaemonConfigurations(existing *Config, flags *flag.FlagSet, configFile string) (*Config, error) {
+	fileConfig, err := LoadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	setInFile, err := presentKeys(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := *fileConfig
+	merged.TLSOptions = existing.TLSOptions
+
+	var conflicts []string
+	for _, f := range fields(existing) {
+		flagSet := flags.IsSet(f.flagName)
+		fileSet := setInFile[f.key]
+		fileVal := f.file(fileConfig)
+		flagVal := f.file(existing)
+
+		if flagSet && fileSet && !valuesEqual(fileVal, flagVal) {
+			conflicts = append(conflicts, f.key)
+			continue
+		}
+		if flagSet {
+			setField(&merged, f.key, flagVal)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("在配置文件和命令行参数中发现冲突的配置项: %v", conflicts)
+	}
+
+	return &merged, nil
+}
+
+// valuesEqual compares two field values as returned by field.file. The
+// only non-comparable type in play is []string (Hosts), so that gets a
+// dedicated comparison; everything else can use Go's built-in ==.
+func valuesEqual(a, b interface{}) bool {
+	as, aIsSlice := a.([]string)
+	bs, bIsSlice := b.([]string)
+	if aIsSlice || bIsSlice {
+		if len(as) != len(bs) {
+			return false
+		}
+		for i := range as {
+			if as[i] != bs[i] {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func setField(cfg *Config, key string, value interface{}) {
+	switch key {
+	case "debug":
+		cfg.Debug = value.(bool)
+	case "hosts":
+		cfg.Hosts = value.([]string)
+	case "log-level":
+		cfg.LogLevel = value.(string)
+	case "tls":
+		cfg.TLS = value.(bool)
+	case "tlsverify":
+		cfg.TLSVerify = value.(bool)
+	case "tlscacert":
+		cfg.TLSCACert = value.(string)
+	case "tlscert":
+		cfg.TLSCert = value.(string)
+	case "tlskey":
+		cfg.TLSKey = value.(string)
+	case "storage-driver":
+		cfg.GraphDriver = value.(string)
+	case "group":
+		cfg.GroupName = value.(string)
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/cli/flags"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+func newConfigFile(t *testing.T, dir, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, "daemon.json")
+	if contents == "" {
+		return path
+	}
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestMergeDaemonConfigurationsNoFile reproduces the single most common
+// daemon invocation: a flag is set on the command line and no
+// configuration file exists. The compiled-in default baked into New()
+// (LogLevel "info") must not be mistaken for something the file
+// explicitly set.
+func TestMergeDaemonConfigurationsNoFile(t *testing.T) {
+	cmd := flag.NewFlagSet("test", flag.ContinueOnError)
+	existing := New()
+	cmd.StringVar(&existing.LogLevel, []string{"-log-level"}, "info", "")
+	if err := cmd.Parse([]string{"--log-level=debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "daemon-config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	merged, err := MergeDaemonConfigurations(existing, cmd, newConfigFile(t, dir, ""))
+	if err != nil {
+		t.Fatalf("没有配置文件时不应报冲突, 实际返回: %v", err)
+	}
+	if merged.LogLevel != "debug" {
+		t.Fatalf("期望合并后的日志级别为 debug, 实际为 %s", merged.LogLevel)
+	}
+}
+
+// TestMergeDaemonConfigurationsFileWins covers the other half of the
+// precedence rule: when the flag was left at its default, the file's
+// explicit value must be used instead of New()'s default.
+func TestMergeDaemonConfigurationsFileWins(t *testing.T) {
+	cmd := flag.NewFlagSet("test", flag.ContinueOnError)
+	existing := New()
+	cmd.StringVar(&existing.LogLevel, []string{"-log-level"}, "info", "")
+	if err := cmd.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "daemon-config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	merged, err := MergeDaemonConfigurations(existing, cmd, newConfigFile(t, dir, `{"log-level": "warn"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged.LogLevel != "warn" {
+		t.Fatalf("期望文件中的日志级别生效, 实际为 %s", merged.LogLevel)
+	}
+}
+
+// TestMergeDaemonConfigurationsConflict covers a scalar field
+// (log-level); TestMergeDaemonConfigurationsHostsConflict below covers
+// Hosts, which must get the same conflict treatment despite needing
+// slice-aware comparison.
+func TestMergeDaemonConfigurationsConflict(t *testing.T) {
+	cmd := flag.NewFlagSet("test", flag.ContinueOnError)
+	existing := New()
+	cmd.StringVar(&existing.LogLevel, []string{"-log-level"}, "info", "")
+	if err := cmd.Parse([]string{"--log-level=debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "daemon-config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := MergeDaemonConfigurations(existing, cmd, newConfigFile(t, dir, `{"log-level": "warn"}`)); err == nil {
+		t.Fatal("期望日志级别冲突时返回错误, 实际没有出错")
+	}
+}
+
+func TestMergeDaemonConfigurationsHostsConflict(t *testing.T) {
+	cmd := flag.NewFlagSet("test", flag.ContinueOnError)
+	existing := New()
+	existing.Hosts = nil
+	cmd.Var(opts.NewListOptsRef(&existing.Hosts, nil), []string{"H", "-host"}, "")
+	if err := cmd.Parse([]string{"--host=tcp://127.0.0.1:2375"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "daemon-config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := MergeDaemonConfigurations(existing, cmd, newConfigFile(t, dir, `{"hosts": ["unix:///var/run/docker.sock"]}`)); err == nil {
+		t.Fatal("期望hosts冲突时返回错误, 实际没有出错")
+	}
+}
+
+// TestMergeDaemonConfigurationsHostsConflictViaEnv guards the interaction
+// a review flagged between flags.Resolve and MergeDaemonConfigurations:
+// a host set through DOCKER_HOST must be treated as "explicitly set",
+// exactly like one passed as --host, so it gets the same conflict
+// protection against daemon.json rather than being silently overridden.
+func TestMergeDaemonConfigurationsHostsConflictViaEnv(t *testing.T) {
+	cmd := flag.NewFlagSet("test", flag.ContinueOnError)
+	existing := New()
+	existing.Hosts = nil
+	cmd.Var(opts.NewListOptsRef(&existing.Hosts, nil), []string{"H", "-host"}, "")
+
+	os.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	defer os.Unsetenv("DOCKER_HOST")
+	flags.Resolve(cmd, "DOCKER", nil)
+
+	if err := cmd.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "daemon-config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := MergeDaemonConfigurations(existing, cmd, newConfigFile(t, dir, `{"hosts": ["unix:///var/run/docker.sock"]}`)); err == nil {
+		t.Fatal("期望通过DOCKER_HOST设置的值与daemon.json冲突时也返回错误")
+	}
+}
@@ -0,0 +1,55 @@
+// +build !windows
+
+package config
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Sirupsen/logrus"
+	flag "github.com/docker/docker/pkg/mflag"
+)
+
+// reloadableFields is the whitelist of configuration keys HandleSIGHUP
+// is allowed to hot-apply; everything else requires a daemon restart.
+var reloadableFields = map[string]bool{
+	"log-level": true,
+	"debug":     true,
+}
+
+// Reload re-reads configFile, merges it with the flags already parsed
+// into flags, restricts the result to reloadableFields, and hands it to
+// apply. It is the unit of work a SIGHUP handler performs on each
+// signal; it does not itself listen for the signal.
+func Reload(existing *Config, flags *flag.FlagSet, configFile string, apply func(*Config)) error {
+	merged, err := MergeDaemonConfigurations(existing, flags, configFile)
+	if err != nil {
+		return err
+	}
+
+	reloaded := *existing
+	for _, f := range fields(existing) {
+		if reloadableFields[f.key] {
+			setField(&reloaded, f.key, f.file(merged))
+		}
+	}
+
+	apply(&reloaded)
+	return nil
+}
+
+// HandleSIGHUP installs a signal handler that calls Reload every time
+// the daemon receives SIGHUP, logging rather than exiting on error so a
+// bad edit to the config file can't take down a running daemon.
+func HandleSIGHUP(existing *Config, flags *flag.FlagSet, configFile string, apply func(*Config)) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for range c {
+			if err := Reload(existing, flags, configFile, apply); err != nil {
+				logrus.Errorf("重新加载配置文件失败: %v", err)
+			}
+		}
+	}()
+}